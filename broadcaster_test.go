@@ -0,0 +1,44 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBroadcasterPublishSubscribe(t *testing.T) {
+	b := NewBroadcaster()
+	events, unsubscribe := b.Subscribe()
+	defer unsubscribe()
+
+	b.Publish(Event{Type: "cart.tick"})
+
+	select {
+	case event := <-events:
+		assert.Equal(t, "cart.tick", event.Type)
+	case <-time.After(time.Second):
+		t.Fatal("expected to receive published event")
+	}
+}
+
+func TestBroadcasterUnsubscribe(t *testing.T) {
+	b := NewBroadcaster()
+	events, unsubscribe := b.Subscribe()
+	unsubscribe()
+
+	b.Publish(Event{Type: "cart.tick"})
+
+	_, ok := <-events
+	assert.False(t, ok, "expected channel to be closed after unsubscribe")
+}
+
+func TestBroadcasterDropsSlowSubscriber(t *testing.T) {
+	b := NewBroadcaster()
+	_, unsubscribe := b.Subscribe()
+	defer unsubscribe()
+
+	for i := 0; i < 32; i++ {
+		b.Publish(Event{Type: "cart.tick"})
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrTimeout is returned by Subscription and OrderHandler channel I/O when
+// a configured deadline elapses before the operation completes.
+var ErrTimeout = errors.New("beer: i/o timeout")
+
+// deadlineTimer implements a resettable deadline on top of a cancel
+// channel, modeled on the deadlineTimer used by net.Pipe. Callers select
+// on wait() alongside their I/O to observe when the deadline fires.
+type deadlineTimer struct {
+	mu       sync.Mutex
+	timer    *time.Timer
+	cancel   chan struct{}
+	deadline time.Time
+}
+
+// newDeadlineTimer returns a deadlineTimer with no deadline set.
+func newDeadlineTimer() *deadlineTimer {
+	return &deadlineTimer{cancel: make(chan struct{})}
+}
+
+// isClosed reports whether ch has already been closed.
+func isClosed(ch chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
+
+// set arms the deadline for t. A zero t clears the deadline. A t in the
+// past fires immediately.
+func (d *deadlineTimer) set(t time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.timer != nil && !d.timer.Stop() {
+		<-d.cancel
+	}
+	d.timer = nil
+	d.deadline = t
+
+	closed := isClosed(d.cancel)
+	if t.IsZero() {
+		if closed {
+			d.cancel = make(chan struct{})
+		}
+		return
+	}
+
+	if dur := time.Until(t); dur > 0 {
+		if closed {
+			d.cancel = make(chan struct{})
+		}
+		cancel := d.cancel
+		d.timer = time.AfterFunc(dur, func() {
+			close(cancel)
+		})
+		return
+	}
+
+	// deadline already passed
+	if !closed {
+		close(d.cancel)
+	}
+}
+
+// wait returns the channel that closes when the deadline fires.
+func (d *deadlineTimer) wait() chan struct{} {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.cancel
+}
+
+// until returns the duration remaining before the deadline and true, or
+// false if no deadline is currently set.
+func (d *deadlineTimer) until() (time.Duration, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.deadline.IsZero() {
+		return 0, false
+	}
+	return time.Until(d.deadline), true
+}
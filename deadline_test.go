@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeadlineTimer(t *testing.T) {
+	d := newDeadlineTimer()
+
+	select {
+	case <-d.wait():
+		t.Fatal("expected deadline to not have fired yet")
+	default:
+	}
+
+	d.set(time.Now().Add(20 * time.Millisecond))
+	select {
+	case <-d.wait():
+	case <-time.After(time.Second):
+		t.Fatal("expected deadline to fire")
+	}
+
+	// Clearing the deadline should arm a fresh, unfired channel.
+	d.set(time.Time{})
+	select {
+	case <-d.wait():
+		t.Fatal("expected deadline to be cleared")
+	default:
+	}
+
+	// A deadline already in the past fires immediately.
+	d.set(time.Now().Add(-time.Second))
+	select {
+	case <-d.wait():
+	default:
+		t.Fatal("expected past deadline to have already fired")
+	}
+}
+
+func TestOrderHandlerReadDeadline(t *testing.T) {
+	js := startTestJetStream(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	handler, err := NewOrderHandler(ctx, js, NewMemoryStore())
+	if err != nil {
+		t.Fatalf("new order handler: %v", err)
+	}
+	handler.SetReadDeadline(time.Now().Add(20 * time.Millisecond))
+
+	consumer, err := handler.stream.CreateOrUpdateConsumer(ctx, jetstream.ConsumerConfig{
+		Durable:       "deadline-test-consumer",
+		AckPolicy:     jetstream.AckExplicitPolicy,
+		FilterSubject: ordersSubjectPattern,
+	})
+	if err != nil {
+		t.Fatalf("create consumer: %v", err)
+	}
+
+	_, err = handler.nextMessage(ctx, consumer)
+	assert.ErrorIs(t, err, ErrTimeout)
+}
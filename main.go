@@ -1,15 +1,15 @@
 package main
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
-	"fmt"
-	"io/ioutil"
 	"net/http"
-	"sync"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
 	"github.com/sirupsen/logrus"
 )
 
@@ -51,109 +51,13 @@ func (c *Cart) Subtotal() float64 {
 	return subtotal
 }
 
-// ProcessPayment sends the total to an external payment api.
-func ProcessPayment(paymentServer string, total float64) ([]byte, error) {
-	b, _ := json.Marshal(total)
-	resp, err := http.Post(paymentServer, "application/json", bytes.NewBuffer(b))
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode >= 400 {
-		return nil, fmt.Errorf("payment server error: %d", resp.StatusCode)
-	}
-	return ioutil.ReadAll(resp.Body)
-}
-
-// PlaceOrder places the order in the warehouse.
-func (o *OrderHandler) PlaceOrder(ctx context.Context, cart *Cart) error {
-	o.ProcessedOrders = append(o.ProcessedOrders, cart)
-	return nil
-}
-
-// OrderHandler represents a concurrent order handler.
-type OrderHandler struct {
-	ProcessedOrders []*Cart
-	messageChan     chan interface{}
-}
-
 var logger = logrus.WithFields(logrus.Fields{
 	"component": "beer",
 })
 
-// startOrderHandler listens to the message channel and handles incoming orders.
-func (o *OrderHandler) startOrderHandler(ctx context.Context) {
-	for {
-		msg, ok := <-o.messageChan
-		if !ok {
-			logger.Debug("message channel closed")
-			return
-		}
-
-		cart, ok := msg.(*Cart)
-		if ok {
-			if err := o.PlaceOrder(ctx, cart); err != nil {
-				logger.WithError(err).Error("error placing order")
-				continue
-			}
-			logger.Info("successfully placed order")
-			continue
-		}
-
-		logger.WithField("msg", msg).Errorf("received invalid message on message channel")
-	}
-}
-
-// Subscription represents a shopping cart.
-type Subscription struct {
-	cart        *Cart
-	interval    time.Duration
-	messageChan chan interface{}
-	mu          sync.Mutex
-}
-
-// GetCart safely retrieves the subscriptions shopping cart.
-func (s *Subscription) GetCart() *Cart {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	return s.cart
-}
-
-// SetCart safely sets the subscriptions shopping cart.
-func (s *Subscription) SetCart(c *Cart) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	s.cart = c
-}
-
-// GetInterval safely retrieves the subscriptions interval.
-func (s *Subscription) GetInterval() time.Duration {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	return s.interval
-}
-
-// SetInterval safely sets the subscriptions interval.
-func (s *Subscription) SetInterval(t time.Duration) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	s.interval = t
-}
-
-// startSubscriptionTimer starts a timer and fires the cart to the
-// order handler when the order is ready.
-func (s *Subscription) startSubscriptionTimer(ctx context.Context) {
-	ticker := time.NewTicker(s.GetInterval())
-	defer ticker.Stop()
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		case <-ticker.C:
-			s.messageChan <- s.GetCart()
-		}
-	}
-}
+// shutdownTimeout bounds how long main waits for in-flight HTTP requests
+// to finish once a shutdown signal arrives.
+const shutdownTimeout = 5 * time.Second
 
 // FixtureBeer creates a Beer fixture for use in test.
 func FixtureBeer(brand string, name string, ounces float64) *Beer {
@@ -180,4 +84,67 @@ func FixtureCart() *Cart {
 	}
 }
 
-func main() {}
+// envOr returns the value of the named environment variable, or fallback
+// if it is unset or empty.
+func envOr(name, fallback string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+	return fallback
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	natsURL := envOr("NATS_URL", nats.DefaultURL)
+	nc, err := nats.Connect(natsURL)
+	if err != nil {
+		logger.WithError(err).Fatal("error connecting to nats")
+	}
+	defer nc.Close()
+
+	js, err := jetstream.New(nc)
+	if err != nil {
+		logger.WithError(err).Fatal("error creating jetstream context")
+	}
+
+	store, err := newStore(ctx)
+	if err != nil {
+		logger.WithError(err).Fatal("error creating store")
+	}
+
+	orderHandler, err := NewOrderHandler(ctx, js, store)
+	if err != nil {
+		logger.WithError(err).Fatal("error creating order handler")
+	}
+
+	broadcaster := NewBroadcaster()
+	orderHandler.SetBroadcaster(broadcaster)
+
+	go func() {
+		if err := orderHandler.startOrderHandler(ctx); err != nil {
+			logger.WithError(err).Error("order handler stopped")
+		}
+	}()
+
+	srv := NewServer(ctx, orderHandler, broadcaster)
+	httpSrv := &http.Server{
+		Addr:    envOr("LISTEN_ADDR", ":8080"),
+		Handler: srv.Routes(),
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := httpSrv.Shutdown(shutdownCtx); err != nil {
+			logger.WithError(err).Error("error shutting down http server")
+		}
+	}()
+
+	logger.WithField("addr", httpSrv.Addr).Info("listening")
+	if err := httpSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		logger.WithError(err).Fatal("error serving http")
+	}
+}
@@ -2,12 +2,14 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
 
+	"github.com/nats-io/nats.go/jetstream"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -110,49 +112,118 @@ func TestProcessPayment(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			ts := httptest.NewServer(http.HandlerFunc(tc.handler))
 			defer ts.Close()
-			body, err := ProcessPayment(ts.URL, 21.11)
+			client := NewPaymentClient(ts.URL, "key", "secret")
+			body, err := client.ProcessPayment(context.Background(), 21.11)
 			assert.Equal(t, tc.expectedError, err)
 			assert.Equal(t, tc.expectedBody, body)
 		})
 	}
 }
 
+func TestProcessPaymentSignsRequest(t *testing.T) {
+	var gotHeaders http.Header
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHeaders = r.Header
+		w.Write([]byte(`OK`))
+	}))
+	defer ts.Close()
+
+	client := NewPaymentClient(ts.URL, "api-key", "api-secret", WithRecvWindow(10*time.Second))
+	_, err := client.ProcessPayment(context.Background(), 21.11)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "api-key", gotHeaders.Get("X-API-KEY"))
+	assert.Equal(t, "10000", gotHeaders.Get("X-RECV-WINDOW"))
+	assert.NotEmpty(t, gotHeaders.Get("X-SIGN"))
+	assert.NotEmpty(t, gotHeaders.Get("X-TIMESTAMP"))
+}
+
 func TestStartSubscriptionTimer(t *testing.T) {
-	ctx := context.Background()
+	js := startTestJetStream(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
 	cart1 := &Cart{Cases: []*Case{FixtureCase(4, FixtureBeer("Duvel", "Tripel Hop", 11.0), 14)}}
 	cart2 := &Cart{Cases: []*Case{FixtureCase(30, FixtureBeer("Labatt", "Blue Light", 12.0), 24)}}
-	subscription := &Subscription{
-		cart:        cart1,
-		interval:    time.Duration(1) * time.Second,
-		messageChan: make(chan interface{}),
+
+	subscription, err := NewSubscription(ctx, js, NewMemoryStore(), "sub-1", time.Duration(1)*time.Second)
+	if err != nil {
+		t.Fatalf("new subscription: %v", err)
+	}
+	subscription.SetCart(cart1)
+
+	stream, err := ensureOrderStream(ctx, js)
+	if err != nil {
+		t.Fatalf("ensure order stream: %v", err)
+	}
+	consumer, err := stream.CreateOrUpdateConsumer(ctx, jetstream.ConsumerConfig{
+		Durable:       "test-consumer",
+		AckPolicy:     jetstream.AckExplicitPolicy,
+		FilterSubject: ordersSubjectPattern,
+	})
+	if err != nil {
+		t.Fatalf("create consumer: %v", err)
 	}
 
 	go subscription.startSubscriptionTimer(ctx)
-	msg := <-subscription.messageChan
-	order, ok := msg.(*Cart)
-	if !ok {
-		t.Fatal("received invalid message on message channel")
+
+	msg, err := consumer.Next()
+	if err != nil {
+		t.Fatalf("receive first cart: %v", err)
 	}
-	assert.Equal(t, cart1, order)
+	var order Cart
+	assert.NoError(t, json.Unmarshal(msg.Data(), &order))
+	assert.Equal(t, *cart1, order)
+	assert.NoError(t, msg.Ack())
 
 	subscription.SetCart(cart2)
-	msg = <-subscription.messageChan
-	order, ok = msg.(*Cart)
-	if !ok {
-		t.Fatal("received invalid message on message channel")
+
+	msg, err = consumer.Next()
+	if err != nil {
+		t.Fatalf("receive second cart: %v", err)
 	}
-	assert.Equal(t, cart2, order)
+	assert.NoError(t, json.Unmarshal(msg.Data(), &order))
+	assert.Equal(t, *cart2, order)
+	assert.NoError(t, msg.Ack())
 }
 
 func TestStartOrderHandler(t *testing.T) {
-	handler := &OrderHandler{
-		messageChan: make(chan interface{}),
+	js := startTestJetStream(t)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	store := NewMemoryStore()
+	handler, err := NewOrderHandler(ctx, js, store)
+	if err != nil {
+		t.Fatalf("new order handler: %v", err)
 	}
-	go handler.startOrderHandler(context.Background())
-	assert.Equal(t, 0, len(handler.ProcessedOrders))
 
-	handler.messageChan <- FixtureCart()
-	handler.messageChan <- FixtureCart()
-	handler.messageChan <- FixtureCase(30, FixtureBeer("Labatt", "Blue Light", 12.0), 24)
-	assert.Equal(t, 2, len(handler.ProcessedOrders))
+	done := make(chan error, 1)
+	go func() { done <- handler.startOrderHandler(ctx) }()
+
+	stats, err := store.Stats(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, stats.OrdersPlaced)
+
+	publishCart := func(id string, cart *Cart) {
+		b, err := json.Marshal(cart)
+		if err != nil {
+			t.Fatalf("marshal cart: %v", err)
+		}
+		ack, err := js.Publish(ctx, orderSubject(id), b)
+		if err != nil {
+			t.Fatalf("publish cart: %v", err)
+		}
+		_ = ack
+	}
+
+	publishCart("sub-1", FixtureCart())
+	publishCart("sub-2", FixtureCart())
+
+	assert.Eventually(t, func() bool {
+		stats, err := store.Stats(ctx)
+		return err == nil && stats.OrdersPlaced == 2
+	}, 2*time.Second, 10*time.Millisecond)
+
+	cancel()
+	<-done
 }
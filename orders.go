@@ -0,0 +1,352 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+const (
+	// ordersStreamName is the JetStream stream backing ready-cart delivery.
+	ordersStreamName = "ORDERS"
+	// ordersSubjectPattern is the wildcard subject the stream captures;
+	// individual subscriptions publish to beer.orders.<subscription id>.
+	ordersSubjectPattern = "beer.orders.*"
+	// orderHandlerDurable is the durable consumer name used to process
+	// ready carts off the orders stream.
+	orderHandlerDurable = "order-handler"
+)
+
+// ensureOrderStream creates (or reuses) the durable stream that carries
+// ready carts from subscriptions to the order handler.
+func ensureOrderStream(ctx context.Context, js jetstream.JetStream) (jetstream.Stream, error) {
+	return js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:     ordersStreamName,
+		Subjects: []string{ordersSubjectPattern},
+	})
+}
+
+// orderSubject returns the subject a subscription publishes ready carts to.
+func orderSubject(subscriptionID string) string {
+	return fmt.Sprintf("beer.orders.%s", subscriptionID)
+}
+
+// OrderHandler consumes ready carts off a JetStream stream and places them
+// in the warehouse, acking or naking the originating message based on the
+// outcome. Placed orders are persisted through a Store rather than held
+// in memory, so they survive restarts and can be read by any process
+// sharing the same store.
+type OrderHandler struct {
+	js     jetstream.JetStream
+	stream jetstream.Stream
+	store  Store
+
+	readDeadline *deadlineTimer
+	broadcaster  *Broadcaster
+
+	orderSeq uint64
+}
+
+// NewOrderHandler creates an OrderHandler backed by the given JetStream
+// context and Store, ensuring the underlying stream exists.
+func NewOrderHandler(ctx context.Context, js jetstream.JetStream, store Store) (*OrderHandler, error) {
+	stream, err := ensureOrderStream(ctx, js)
+	if err != nil {
+		return nil, fmt.Errorf("ensure order stream: %w", err)
+	}
+	return &OrderHandler{
+		js:           js,
+		stream:       stream,
+		store:        store,
+		readDeadline: newDeadlineTimer(),
+	}, nil
+}
+
+// SetReadDeadline sets the deadline by which the next message must arrive
+// off the orders stream. startOrderHandler returns ErrTimeout from its
+// fetch loop, without stopping the handler, once the deadline elapses. A
+// zero value disables the deadline.
+func (o *OrderHandler) SetReadDeadline(t time.Time) {
+	o.readDeadline.set(t)
+}
+
+// SetBroadcaster wires a Broadcaster into the handler so placed orders are
+// published as Events for live consumers (see Server).
+func (o *OrderHandler) SetBroadcaster(b *Broadcaster) {
+	o.broadcaster = b
+}
+
+// nextOrderID generates the Store key a newly placed order is saved
+// under, preferring the originating message's stream sequence so the ID
+// is stable across redelivery.
+func (o *OrderHandler) nextOrderID(msg jetstream.Msg) string {
+	if msg != nil {
+		if md, err := msg.Metadata(); err == nil {
+			return fmt.Sprintf("order:%d", md.Sequence.Stream)
+		}
+	}
+	return fmt.Sprintf("order:local-%d", atomic.AddUint64(&o.orderSeq, 1))
+}
+
+// PlaceOrder places the order in the warehouse by persisting it to the
+// Store. When msg is non-nil it is acked on success or naked on failure,
+// so the JetStream consumer loop can redeliver orders the warehouse
+// failed to accept.
+func (o *OrderHandler) PlaceOrder(ctx context.Context, cart *Cart, msg jetstream.Msg) error {
+	if cart == nil {
+		err := errors.New("cannot place a nil cart")
+		if msg != nil {
+			_ = msg.Nak()
+		}
+		return err
+	}
+
+	if err := o.store.SaveCart(ctx, o.nextOrderID(msg), cart); err != nil {
+		if msg != nil {
+			_ = msg.Nak()
+		}
+		return fmt.Errorf("save order: %w", err)
+	}
+
+	if o.broadcaster != nil {
+		o.broadcaster.Publish(Event{Type: "order.placed", Timestamp: time.Now(), Data: cart})
+	}
+
+	if msg != nil {
+		return msg.Ack()
+	}
+	return nil
+}
+
+// startOrderHandler consumes ready carts off the durable order-handler
+// consumer until ctx is canceled. Each fetch honors SetReadDeadline: if
+// the deadline elapses before a message arrives, the fetch returns
+// ErrTimeout and the loop tries again rather than blocking forever.
+func (o *OrderHandler) startOrderHandler(ctx context.Context) error {
+	consumer, err := o.stream.CreateOrUpdateConsumer(ctx, jetstream.ConsumerConfig{
+		Durable:       orderHandlerDurable,
+		AckPolicy:     jetstream.AckExplicitPolicy,
+		FilterSubject: ordersSubjectPattern,
+	})
+	if err != nil {
+		return fmt.Errorf("create order consumer: %w", err)
+	}
+
+	for {
+		msg, err := o.nextMessage(ctx, consumer)
+		switch {
+		case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+			return nil
+		case errors.Is(err, ErrTimeout):
+			logger.Debug("read deadline exceeded waiting for next order")
+			continue
+		case err != nil:
+			logger.WithError(err).Error("error fetching next order")
+			continue
+		}
+
+		var cart Cart
+		if err := json.Unmarshal(msg.Data(), &cart); err != nil {
+			logger.WithError(err).Error("received invalid message on order subject")
+			_ = msg.Nak()
+			continue
+		}
+
+		if err := o.PlaceOrder(ctx, &cart, msg); err != nil {
+			logger.WithError(err).Error("error placing order")
+			continue
+		}
+		logger.Info("successfully placed order")
+	}
+}
+
+// defaultFetchWait bounds how long a single fetch waits for the next
+// message when no read deadline is configured, so the handler still
+// notices ctx cancellation promptly rather than blocking for the
+// underlying pull request's 30s default.
+const defaultFetchWait = 5 * time.Second
+
+// nextMessage fetches the next message off consumer, waiting at most the
+// handler's read deadline (or defaultFetchWait if none is set). It issues
+// exactly one in-flight pull request at a time: the call blocks for the
+// fetch wait and returns, so a timed-out call never leaves a competing
+// pull request racing a later call for a future message, as a detached
+// consumer.Next() goroutine would.
+func (o *OrderHandler) nextMessage(ctx context.Context, consumer jetstream.Consumer) (jetstream.Msg, error) {
+	wait := defaultFetchWait
+	if remaining, ok := o.readDeadline.until(); ok {
+		if remaining <= 0 {
+			return nil, ErrTimeout
+		}
+		wait = remaining
+	}
+
+	batch, err := consumer.Fetch(1, jetstream.FetchMaxWait(wait))
+	if err != nil {
+		return nil, err
+	}
+
+	select {
+	case msg, ok := <-batch.Messages():
+		if !ok {
+			if err := batch.Error(); err != nil {
+				return nil, err
+			}
+			return nil, ErrTimeout
+		}
+		return msg, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Subscription represents a customer's recurring cart. Ready carts are
+// published to JetStream for the order handler to consume, and the
+// latest cart snapshot is persisted through a Store keyed by
+// subscription ID so it survives restarts.
+type Subscription struct {
+	id       string
+	cart     *Cart
+	interval time.Duration
+
+	js    jetstream.JetStream
+	store Store
+
+	mu            sync.Mutex
+	writeDeadline *deadlineTimer
+	broadcaster   *Broadcaster
+}
+
+// NewSubscription creates a Subscription for id, loading any previously
+// persisted cart snapshot from store.
+func NewSubscription(ctx context.Context, js jetstream.JetStream, store Store, id string, interval time.Duration) (*Subscription, error) {
+	s := &Subscription{
+		id:            id,
+		interval:      interval,
+		js:            js,
+		store:         store,
+		writeDeadline: newDeadlineTimer(),
+	}
+
+	cart, err := store.LoadCart(ctx, id)
+	if err != nil && !errors.Is(err, ErrCartNotFound) {
+		return nil, fmt.Errorf("load cart snapshot: %w", err)
+	}
+	s.cart = cart
+
+	return s, nil
+}
+
+// GetCart safely retrieves the subscriptions shopping cart.
+func (s *Subscription) GetCart() *Cart {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cart
+}
+
+// SetCart safely sets the subscriptions shopping cart and persists the
+// snapshot so it survives a restart.
+func (s *Subscription) SetCart(c *Cart) {
+	s.mu.Lock()
+	s.cart = c
+	s.mu.Unlock()
+
+	if err := s.store.SaveCart(context.Background(), s.id, c); err != nil {
+		logger.WithError(err).Error("error persisting cart snapshot")
+	}
+}
+
+// GetInterval safely retrieves the subscriptions interval.
+func (s *Subscription) GetInterval() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.interval
+}
+
+// SetInterval safely sets the subscriptions interval.
+func (s *Subscription) SetInterval(t time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.interval = t
+}
+
+// startSubscriptionTimer starts a timer and publishes the cart to the
+// order handler's JetStream subject when the order is ready.
+func (s *Subscription) startSubscriptionTimer(ctx context.Context) {
+	ticker := time.NewTicker(s.GetInterval())
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.publishCart(ctx); err != nil {
+				logger.WithError(err).Error("error publishing cart")
+			}
+		}
+	}
+}
+
+// SetWriteDeadline sets the deadline by which a publishCart call must
+// complete. publishCart returns ErrTimeout if the deadline elapses before
+// the publish acknowledges. A zero value disables the deadline.
+func (s *Subscription) SetWriteDeadline(t time.Time) {
+	s.writeDeadline.set(t)
+}
+
+// SetBroadcaster wires a Broadcaster into the subscription so cart ticks
+// are published as Events for live consumers (see Server).
+func (s *Subscription) SetBroadcaster(b *Broadcaster) {
+	s.mu.Lock()
+	s.broadcaster = b
+	s.mu.Unlock()
+}
+
+// publishCart publishes the current cart snapshot to this subscription's
+// order subject, bounding the publish by the subscription's write
+// deadline so a slow or unreachable JetStream server cannot block the
+// subscription timer forever. It issues a single in-flight Publish
+// against a context derived from the deadline rather than racing a
+// detached goroutine, so a timed-out call never leaves a publish running
+// past the caller's knowledge of it, and never broadcasts a stale
+// cart.tick for a call the caller already saw fail.
+func (s *Subscription) publishCart(ctx context.Context) error {
+	b, err := json.Marshal(s.GetCart())
+	if err != nil {
+		return fmt.Errorf("marshal cart: %w", err)
+	}
+
+	publishCtx := ctx
+	if remaining, ok := s.writeDeadline.until(); ok {
+		if remaining <= 0 {
+			return ErrTimeout
+		}
+		var cancel context.CancelFunc
+		publishCtx, cancel = context.WithTimeout(ctx, remaining)
+		defer cancel()
+	}
+
+	if _, err := s.js.Publish(publishCtx, orderSubject(s.id), b); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if errors.Is(publishCtx.Err(), context.DeadlineExceeded) {
+			return ErrTimeout
+		}
+		return err
+	}
+
+	s.mu.Lock()
+	broadcaster := s.broadcaster
+	s.mu.Unlock()
+	if broadcaster != nil {
+		broadcaster.Publish(Event{Type: "cart.tick", Timestamp: time.Now(), Data: s.GetCart()})
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// defaultRecvWindow is the window, in milliseconds, during which the
+// payment server will accept a signed request before rejecting it as
+// stale.
+const defaultRecvWindow = 5 * time.Second
+
+// PaymentClient sends signed payment requests to an external payment api.
+type PaymentClient struct {
+	BaseURL    string
+	APIKey     string
+	APISecret  string
+	HTTPClient *http.Client
+	Debug      bool
+	RecvWindow time.Duration
+}
+
+// PaymentClientOption configures a PaymentClient.
+type PaymentClientOption func(*PaymentClient)
+
+// WithDebug enables debug logging of outgoing payment requests.
+func WithDebug(debug bool) PaymentClientOption {
+	return func(c *PaymentClient) {
+		c.Debug = debug
+	}
+}
+
+// WithHTTPClient overrides the *http.Client used to send payment requests.
+func WithHTTPClient(httpClient *http.Client) PaymentClientOption {
+	return func(c *PaymentClient) {
+		c.HTTPClient = httpClient
+	}
+}
+
+// WithRecvWindow overrides the default recv-window sent with signed
+// requests.
+func WithRecvWindow(d time.Duration) PaymentClientOption {
+	return func(c *PaymentClient) {
+		c.RecvWindow = d
+	}
+}
+
+// NewPaymentClient creates a PaymentClient that signs requests to
+// baseURL with the given API key/secret pair.
+func NewPaymentClient(baseURL, apiKey, apiSecret string, opts ...PaymentClientOption) *PaymentClient {
+	c := &PaymentClient{
+		BaseURL:    baseURL,
+		APIKey:     apiKey,
+		APISecret:  apiSecret,
+		HTTPClient: http.DefaultClient,
+		RecvWindow: defaultRecvWindow,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// sign computes the hex-encoded HMAC-SHA256 signature for a signed
+// payment request, matching the exchange-style convention of
+// HMAC-SHA256(timestamp + apiKey + recvWindow + body).
+func (c *PaymentClient) sign(timestamp, recvWindow int64, body []byte) string {
+	payload := fmt.Sprintf("%d%s%d%s", timestamp, c.APIKey, recvWindow, body)
+	mac := hmac.New(sha256.New, []byte(c.APISecret))
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// ProcessPayment sends the total to the payment server as an
+// HMAC-signed request, authenticated with the client's API key/secret.
+func (c *PaymentClient) ProcessPayment(ctx context.Context, total float64) ([]byte, error) {
+	body, _ := json.Marshal(total)
+
+	timestamp := time.Now().UnixMilli()
+	recvWindow := c.RecvWindow.Milliseconds()
+	sig := c.sign(timestamp, recvWindow, body)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.BaseURL, bytes.NewBuffer(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-API-KEY", c.APIKey)
+	req.Header.Set("X-SIGN", sig)
+	req.Header.Set("X-TIMESTAMP", strconv.FormatInt(timestamp, 10))
+	req.Header.Set("X-RECV-WINDOW", strconv.FormatInt(recvWindow, 10))
+
+	if c.Debug {
+		logger.WithFields(map[string]interface{}{
+			"timestamp":   timestamp,
+			"recv_window": recvWindow,
+		}).Debug("sending signed payment request")
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("payment server error: %d", resp.StatusCode)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
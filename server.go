@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Stats summarizes aggregate order and subscription activity, served at
+// GET /api/v1/stats.
+type Stats struct {
+	OrdersPlaced        int     `json:"orders_placed"`
+	TotalSubtotal       float64 `json:"total_subtotal"`
+	ActiveSubscriptions int     `json:"active_subscriptions"`
+}
+
+// Server exposes HTTP endpoints for observing live cart and order
+// activity: an SSE event stream, an aggregate stats snapshot, and
+// creation of the Subscriptions that actually produce that activity.
+type Server struct {
+	ctx          context.Context
+	orderHandler *OrderHandler
+	broadcaster  *Broadcaster
+
+	mu                  sync.Mutex
+	activeSubscriptions int
+}
+
+// NewServer creates a Server backed by orderHandler and broadcaster.
+// ctx bounds the lifetime of any Subscription started through
+// handleCreateSubscription: they stop when ctx is done.
+func NewServer(ctx context.Context, orderHandler *OrderHandler, broadcaster *Broadcaster) *Server {
+	return &Server{
+		ctx:          ctx,
+		orderHandler: orderHandler,
+		broadcaster:  broadcaster,
+	}
+}
+
+// TrackSubscription marks a subscription as active for the purposes of
+// GET /api/v1/stats. Callers should invoke the returned function when the
+// subscription stops.
+func (srv *Server) TrackSubscription() func() {
+	srv.mu.Lock()
+	srv.activeSubscriptions++
+	srv.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			srv.mu.Lock()
+			srv.activeSubscriptions--
+			srv.mu.Unlock()
+		})
+	}
+}
+
+// Stats computes the current aggregate stats, sourcing order counts from
+// the order handler's Store and overlaying the active subscription count
+// this Server is tracking.
+func (srv *Server) Stats(ctx context.Context) (Stats, error) {
+	stats, err := srv.orderHandler.store.Stats(ctx)
+	if err != nil {
+		return Stats{}, err
+	}
+
+	srv.mu.Lock()
+	stats.ActiveSubscriptions = srv.activeSubscriptions
+	srv.mu.Unlock()
+
+	return stats, nil
+}
+
+// Routes returns the Server's http.Handler, mounting /api/v1/events,
+// /api/v1/stats, and /api/v1/subscriptions.
+func (srv *Server) Routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/events", srv.handleEvents)
+	mux.HandleFunc("/api/v1/stats", srv.handleStats)
+	mux.HandleFunc("/api/v1/subscriptions", srv.handleCreateSubscription)
+	return mux
+}
+
+// handleEvents streams cart and order Events to the client as
+// Server-Sent Events until the client disconnects.
+func (srv *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	events, unsubscribe := srv.broadcaster.Subscribe()
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			b, err := json.Marshal(event)
+			if err != nil {
+				logger.WithError(err).Error("error marshaling event")
+				continue
+			}
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, b)
+			flusher.Flush()
+		}
+	}
+}
+
+// createSubscriptionRequest is the POST /api/v1/subscriptions payload.
+type createSubscriptionRequest struct {
+	ID              string  `json:"id"`
+	IntervalSeconds float64 `json:"interval_seconds"`
+}
+
+// handleCreateSubscription starts a Subscription ticking on its own
+// interval until the Server's context is done, publishing ready carts to
+// the order handler and cart.tick Events along the way. It's how the
+// service actually produces the activity GET /api/v1/stats and
+// /api/v1/events report, rather than requiring a client to publish to
+// beer.orders.* directly.
+func (srv *Server) handleCreateSubscription(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req createSubscriptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.ID == "" || req.IntervalSeconds <= 0 {
+		http.Error(w, "id and interval_seconds are required", http.StatusBadRequest)
+		return
+	}
+
+	interval := time.Duration(req.IntervalSeconds * float64(time.Second))
+	sub, err := NewSubscription(r.Context(), srv.orderHandler.js, srv.orderHandler.store, req.ID, interval)
+	if err != nil {
+		logger.WithError(err).Error("error creating subscription")
+		http.Error(w, "error creating subscription", http.StatusInternalServerError)
+		return
+	}
+	sub.SetBroadcaster(srv.broadcaster)
+
+	stopTracking := srv.TrackSubscription()
+	go func() {
+		defer stopTracking()
+		sub.startSubscriptionTimer(srv.ctx)
+	}()
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// handleStats responds with the current Stats snapshot as JSON.
+func (srv *Server) handleStats(w http.ResponseWriter, r *http.Request) {
+	stats, err := srv.Stats(r.Context())
+	if err != nil {
+		http.Error(w, "error computing stats", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(stats); err != nil {
+		logger.WithError(err).Error("error encoding stats")
+	}
+}
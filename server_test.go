@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServerStats(t *testing.T) {
+	js := startTestJetStream(t)
+	ctx := context.Background()
+
+	handler, err := NewOrderHandler(ctx, js, NewMemoryStore())
+	if err != nil {
+		t.Fatalf("new order handler: %v", err)
+	}
+	broadcaster := NewBroadcaster()
+	handler.SetBroadcaster(broadcaster)
+
+	srv := NewServer(ctx, handler, broadcaster)
+	stopTracking := srv.TrackSubscription()
+	defer stopTracking()
+
+	assert.NoError(t, handler.PlaceOrder(ctx, FixtureCart(), nil))
+
+	stats, err := srv.Stats(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, stats.OrdersPlaced)
+	assert.Equal(t, FixtureCart().Subtotal(), stats.TotalSubtotal)
+	assert.Equal(t, 1, stats.ActiveSubscriptions)
+}
+
+func TestServerHandleStats(t *testing.T) {
+	js := startTestJetStream(t)
+	ctx := context.Background()
+
+	handler, err := NewOrderHandler(ctx, js, NewMemoryStore())
+	if err != nil {
+		t.Fatalf("new order handler: %v", err)
+	}
+	srv := NewServer(ctx, handler, NewBroadcaster())
+
+	ts := httptest.NewServer(srv.Routes())
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/api/v1/stats")
+	if err != nil {
+		t.Fatalf("get stats: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var stats Stats
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&stats))
+	assert.Equal(t, 0, stats.OrdersPlaced)
+}
+
+func TestServerHandleEvents(t *testing.T) {
+	js := startTestJetStream(t)
+	ctx := context.Background()
+
+	handler, err := NewOrderHandler(ctx, js, NewMemoryStore())
+	if err != nil {
+		t.Fatalf("new order handler: %v", err)
+	}
+	broadcaster := NewBroadcaster()
+	handler.SetBroadcaster(broadcaster)
+	srv := NewServer(ctx, handler, broadcaster)
+
+	ts := httptest.NewServer(srv.Routes())
+	defer ts.Close()
+
+	reqCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodGet, ts.URL+"/api/v1/events", nil)
+	if err != nil {
+		t.Fatalf("new request: %v", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("get events: %v", err)
+	}
+	defer resp.Body.Close()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- handler.PlaceOrder(ctx, FixtureCart(), nil)
+	}()
+	assert.NoError(t, <-done)
+
+	buf := make([]byte, 512)
+	n, err := resp.Body.Read(buf)
+	if err != nil {
+		t.Fatalf("read event stream: %v", err)
+	}
+	assert.Contains(t, string(buf[:n]), "order.placed")
+}
+
+func TestServerHandleCreateSubscription(t *testing.T) {
+	js := startTestJetStream(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	handler, err := NewOrderHandler(ctx, js, NewMemoryStore())
+	if err != nil {
+		t.Fatalf("new order handler: %v", err)
+	}
+	srv := NewServer(ctx, handler, NewBroadcaster())
+
+	ts := httptest.NewServer(srv.Routes())
+	defer ts.Close()
+
+	body, err := json.Marshal(createSubscriptionRequest{ID: "sub-1", IntervalSeconds: 0.01})
+	if err != nil {
+		t.Fatalf("marshal request: %v", err)
+	}
+
+	resp, err := http.Post(ts.URL+"/api/v1/subscriptions", "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("post subscription: %v", err)
+	}
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+	assert.Eventually(t, func() bool {
+		stats, err := srv.Stats(ctx)
+		return err == nil && stats.ActiveSubscriptions == 1
+	}, time.Second, 10*time.Millisecond)
+}
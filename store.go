@@ -0,0 +1,33 @@
+package main
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrCartNotFound is returned by Store.LoadCart when no cart has been
+// saved for the given id.
+var ErrCartNotFound = errors.New("beer: cart not found")
+
+// orderIDPrefix marks a Store id as a placed order rather than a
+// subscription's live cart snapshot, matching OrderHandler.nextOrderID.
+const orderIDPrefix = "order:"
+
+// OrderFilter narrows the results returned by Store.ListOrders. The zero
+// value matches every placed order.
+type OrderFilter struct{}
+
+// Store persists cart snapshots and placed orders, so carts and the
+// orders placed from them outlive a single process and can be shared
+// across instances.
+type Store interface {
+	// SaveCart persists cart under id, overwriting any existing value.
+	SaveCart(ctx context.Context, id string, cart *Cart) error
+	// LoadCart returns the cart persisted under id, or ErrCartNotFound if
+	// none exists.
+	LoadCart(ctx context.Context, id string) (*Cart, error)
+	// ListOrders returns placed orders matching filter.
+	ListOrders(ctx context.Context, filter OrderFilter) ([]*Cart, error)
+	// Stats returns aggregate counts derived from placed orders.
+	Stats(ctx context.Context) (Stats, error)
+}
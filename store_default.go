@@ -0,0 +1,12 @@
+//go:build !postgres
+
+package main
+
+import "context"
+
+// newStore builds the Store main() runs against. The default build uses
+// an in-process MemoryStore; build with -tags postgres to persist to a
+// real database instead (see store_pg.go).
+func newStore(ctx context.Context) (Store, error) {
+	return NewMemoryStore(), nil
+}
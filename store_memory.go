@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+// MemoryStore is an in-process Store, used as the default when no
+// external database is configured. Data does not survive a restart.
+type MemoryStore struct {
+	mu    sync.Mutex
+	carts map[string]*Cart
+}
+
+// NewMemoryStore creates an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{carts: make(map[string]*Cart)}
+}
+
+func (m *MemoryStore) SaveCart(ctx context.Context, id string, cart *Cart) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.carts[id] = cart
+	return nil
+}
+
+func (m *MemoryStore) LoadCart(ctx context.Context, id string) (*Cart, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	cart, ok := m.carts[id]
+	if !ok {
+		return nil, ErrCartNotFound
+	}
+	return cart, nil
+}
+
+func (m *MemoryStore) ListOrders(ctx context.Context, filter OrderFilter) ([]*Cart, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var orders []*Cart
+	for id, cart := range m.carts {
+		if strings.HasPrefix(id, orderIDPrefix) {
+			orders = append(orders, cart)
+		}
+	}
+	return orders, nil
+}
+
+func (m *MemoryStore) Stats(ctx context.Context) (Stats, error) {
+	orders, err := m.ListOrders(ctx, OrderFilter{})
+	if err != nil {
+		return Stats{}, err
+	}
+
+	stats := Stats{OrdersPlaced: len(orders)}
+	for _, cart := range orders {
+		stats.TotalSubtotal += cart.Subtotal()
+	}
+	return stats, nil
+}
@@ -0,0 +1,186 @@
+//go:build postgres
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// pgSchema creates the normalized tables PGStore persists carts into:
+// beers are deduplicated by brand/name/ounces, carts track whether they
+// are a live subscription snapshot or a placed order, and cases join the
+// two together.
+const pgSchema = `
+CREATE TABLE IF NOT EXISTS beers (
+	id SERIAL PRIMARY KEY,
+	brand TEXT NOT NULL,
+	name TEXT NOT NULL,
+	ounces DOUBLE PRECISION NOT NULL,
+	UNIQUE (brand, name, ounces)
+);
+
+CREATE TABLE IF NOT EXISTS carts (
+	id TEXT PRIMARY KEY,
+	is_order BOOLEAN NOT NULL DEFAULT false,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS cases (
+	id SERIAL PRIMARY KEY,
+	cart_id TEXT NOT NULL REFERENCES carts(id) ON DELETE CASCADE,
+	beer_id INTEGER NOT NULL REFERENCES beers(id),
+	count INTEGER NOT NULL,
+	price DOUBLE PRECISION NOT NULL
+);
+`
+
+// PGStore is a Postgres-backed Store, normalizing each cart's cases and
+// beers into their own tables.
+type PGStore struct {
+	pool *pgxpool.Pool
+}
+
+// NewPGStore creates a PGStore backed by pool, applying its schema.
+func NewPGStore(ctx context.Context, pool *pgxpool.Pool) (*PGStore, error) {
+	if _, err := pool.Exec(ctx, pgSchema); err != nil {
+		return nil, fmt.Errorf("apply schema: %w", err)
+	}
+	return &PGStore{pool: pool}, nil
+}
+
+// SaveCart upserts the cart record and replaces its cases, deduplicating
+// beers by brand/name/ounces.
+func (s *PGStore) SaveCart(ctx context.Context, id string, cart *Cart) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	isOrder := strings.HasPrefix(id, orderIDPrefix)
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO carts (id, is_order) VALUES ($1, $2)
+		ON CONFLICT (id) DO UPDATE SET is_order = EXCLUDED.is_order
+	`, id, isOrder); err != nil {
+		return fmt.Errorf("upsert cart: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM cases WHERE cart_id = $1`, id); err != nil {
+		return fmt.Errorf("clear cases: %w", err)
+	}
+
+	for _, c := range cart.Cases {
+		var beerID int
+		err := tx.QueryRow(ctx, `
+			INSERT INTO beers (brand, name, ounces) VALUES ($1, $2, $3)
+			ON CONFLICT (brand, name, ounces) DO UPDATE SET brand = EXCLUDED.brand
+			RETURNING id
+		`, c.Beer.Brand, c.Beer.Name, c.Beer.Ounces).Scan(&beerID)
+		if err != nil {
+			return fmt.Errorf("upsert beer: %w", err)
+		}
+
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO cases (cart_id, beer_id, count, price) VALUES ($1, $2, $3, $4)
+		`, id, beerID, c.Count, c.Price); err != nil {
+			return fmt.Errorf("insert case: %w", err)
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// LoadCart reassembles the cart persisted under id from its cases and
+// beers, returning ErrCartNotFound if id has never been saved.
+func (s *PGStore) LoadCart(ctx context.Context, id string) (*Cart, error) {
+	exists, err := s.cartExists(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrCartNotFound
+	}
+	return s.loadCases(ctx, id)
+}
+
+func (s *PGStore) cartExists(ctx context.Context, id string) (bool, error) {
+	var exists bool
+	err := s.pool.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM carts WHERE id = $1)`, id).Scan(&exists)
+	return exists, err
+}
+
+func (s *PGStore) loadCases(ctx context.Context, id string) (*Cart, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT b.brand, b.name, b.ounces, c.count, c.price
+		FROM cases c
+		JOIN beers b ON b.id = c.beer_id
+		WHERE c.cart_id = $1
+	`, id)
+	if err != nil {
+		return nil, fmt.Errorf("query cases: %w", err)
+	}
+	defer rows.Close()
+
+	cart := NewCart()
+	for rows.Next() {
+		var beer Beer
+		var c Case
+		if err := rows.Scan(&beer.Brand, &beer.Name, &beer.Ounces, &c.Count, &c.Price); err != nil {
+			return nil, fmt.Errorf("scan case: %w", err)
+		}
+		c.Beer = &beer
+		cart.AddCase(&c)
+	}
+	return cart, rows.Err()
+}
+
+// ListOrders returns every cart persisted as a placed order, oldest
+// first. filter is currently unused; it exists so future query narrowing
+// doesn't require an interface change.
+func (s *PGStore) ListOrders(ctx context.Context, filter OrderFilter) ([]*Cart, error) {
+	rows, err := s.pool.Query(ctx, `SELECT id FROM carts WHERE is_order ORDER BY created_at`)
+	if err != nil {
+		return nil, fmt.Errorf("query orders: %w", err)
+	}
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	orders := make([]*Cart, 0, len(ids))
+	for _, id := range ids {
+		cart, err := s.loadCases(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, cart)
+	}
+	return orders, nil
+}
+
+// Stats aggregates counts across every placed order.
+func (s *PGStore) Stats(ctx context.Context) (Stats, error) {
+	orders, err := s.ListOrders(ctx, OrderFilter{})
+	if err != nil {
+		return Stats{}, err
+	}
+
+	stats := Stats{OrdersPlaced: len(orders)}
+	for _, cart := range orders {
+		stats.TotalSubtotal += cart.Subtotal()
+	}
+	return stats, nil
+}
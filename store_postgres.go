@@ -0,0 +1,28 @@
+//go:build postgres
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// newStore builds the Store main() runs against. Built with -tags
+// postgres, it connects to the database at DATABASE_URL and persists
+// through PGStore instead of the default in-process MemoryStore.
+func newStore(ctx context.Context) (Store, error) {
+	dsn := os.Getenv("DATABASE_URL")
+	if dsn == "" {
+		return nil, fmt.Errorf("DATABASE_URL must be set for a postgres-tagged build")
+	}
+
+	pool, err := pgxpool.New(ctx, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("connect to postgres: %w", err)
+	}
+
+	return NewPGStore(ctx, pool)
+}